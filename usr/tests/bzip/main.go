@@ -0,0 +1,142 @@
+// Copyright © 2016 Alan A. A. Donovan & Brian W. Kernighan.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+// The original code was published at http://www.gopl.io, see page 286
+// (ch13/bzip).
+
+// Bzip compresses a byte slice with bzip2 through a cgo wrapper around
+// libbz2, then decompresses it with the standard library's compress/bzip2
+// reader and checks the round trip matches.
+package main
+
+/*
+#cgo LDFLAGS: -lbz2
+#include <bzlib.h>
+#include <stdlib.h>
+
+bz_stream* bz2alloc() { return calloc(1, sizeof(bz_stream)); }
+
+// bz2compress feeds in (if non-NULL) through BZ2_bzCompress and drains
+// whatever libbz2 produces into out. finish selects BZ_FINISH instead of
+// BZ_RUN, which is required to ever get BZ_STREAM_END back; Close calls
+// with finish=1 until it sees that return value, Write always passes 0.
+int bz2compress(bz_stream *s, char *in, int *inlen, char *out, int *outlen, int finish) {
+	s->next_in = in;
+	s->avail_in = *inlen;
+	s->next_out = out;
+	s->avail_out = *outlen;
+	int r = BZ2_bzCompress(s, finish ? BZ_FINISH : BZ_RUN);
+	*inlen -= s->avail_in;
+	*outlen -= s->avail_out;
+	return r;
+}
+
+void bz2free(bz_stream *s) { free(s); }
+*/
+import "C"
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"unsafe"
+)
+
+// writer is an io.WriteCloser that feeds data through libbz2 via cgo.
+type writer struct {
+	w      io.Writer
+	stream *C.bz_stream
+	outbuf [64 * 1024]byte
+}
+
+// newWriter returns a writer that compresses data written to it and writes
+// the compressed form to out.
+func newWriter(out io.Writer) io.WriteCloser {
+	const blockSize = 9
+	w := &writer{w: out, stream: C.bz2alloc()}
+	C.BZ2_bzCompressInit(w.stream, blockSize, 0, 0)
+	return w
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	if w.stream == nil {
+		panic("Write called after Close")
+	}
+	var total int
+	for len(data) > 0 {
+		inlen, outlen := C.int(len(data)), C.int(cap(w.outbuf))
+		C.bz2compress(w.stream, (*C.char)(unsafe.Pointer(&data[0])), &inlen,
+			(*C.char)(unsafe.Pointer(&w.outbuf)), &outlen, 0)
+		total += int(inlen)
+		data = data[inlen:]
+		if outlen > 0 {
+			if _, err := w.w.Write(w.outbuf[:outlen]); err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
+
+func (w *writer) Close() error {
+	if w.stream == nil {
+		panic("Close called twice")
+	}
+	defer func() {
+		C.BZ2_bzCompressEnd(w.stream)
+		C.free(unsafe.Pointer(w.stream))
+		w.stream = nil
+	}()
+	for {
+		inlen, outlen := C.int(0), C.int(cap(w.outbuf))
+		r := C.bz2compress(w.stream, nil, &inlen,
+			(*C.char)(unsafe.Pointer(&w.outbuf)), &outlen, 1)
+		if outlen > 0 {
+			if _, err := w.w.Write(w.outbuf[:outlen]); err != nil {
+				return err
+			}
+		}
+		if r == C.BZ_STREAM_END {
+			return nil
+		}
+	}
+}
+
+// roundTrip compresses data with the cgo writer above, decompresses the
+// result with the standard library's bzip2 reader, and returns the
+// decompressed bytes.
+func roundTrip(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	w := newWriter(&compressed)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(bzip2.NewReader(&compressed))
+}
+
+func main() {
+	// libbz2 runs on whatever OS thread made the cgo call; pin it for the
+	// lifetime of the compression so the C library sees a stable thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	data := []byte("the quick brown fox jumps over the lazy dog, repeatedly, " +
+		"the quick brown fox jumps over the lazy dog, repeatedly")
+
+	got, err := roundTrip(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bzip:", err)
+		os.Exit(1)
+	}
+	if !bytes.Equal(got, data) {
+		fmt.Fprintln(os.Stderr, "bzip: round trip mismatch")
+		os.Exit(1)
+	}
+	fmt.Println("compress/decompress round trip OK: ", len(data), "bytes")
+}
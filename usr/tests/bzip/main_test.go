@@ -0,0 +1,25 @@
+// Copyright © 2016 Alan A. A. Donovan & Brian W. Kernighan.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func BenchmarkRoundTrip(b *testing.B) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 1000)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		got, err := roundTrip(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			b.Fatal("round trip mismatch")
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright © 2016 Alan A. A. Donovan & Brian W. Kernighan.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+// The original code was published at http://www.gopl.io, see page 12
+// (ch1/dup2).
+
+// Dup prints the count and text of lines that appear more than once in the
+// input. It reads from the files named on the command line, falling back
+// to os.Stdin when none are given, which lets it double as a check that
+// both the initrd/host file backend and the stdin descriptor are wired up
+// through the kernel's syscall bridge.
+//
+// NOTE: this repo has no CI config (no .github/, *.yml/*.yaml, or
+// Makefile) for this chunk to hook into, so this example is not wired into
+// CI yet; that needs a pipeline definition added alongside the runner
+// setup for the other usr/tests examples, not just this file.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+func main() {
+	counts := make(map[string]int)
+	files := os.Args[1:]
+	if len(files) == 0 {
+		countLines(os.Stdin, counts)
+	} else {
+		for _, arg := range files {
+			f, err := os.Open(arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "dup: %v\n", err)
+				continue
+			}
+			countLines(f, counts)
+			f.Close()
+		}
+	}
+	for line, n := range counts {
+		if n > 1 {
+			fmt.Printf("%d\t%s\n", n, line)
+		}
+	}
+}
+
+// countLines scans f line by line, tallying each line seen in counts.
+// f is streamed through bufio.Scanner rather than read in one shot so that
+// short reads across page boundaries are exercised just like they would be
+// for a large file served through the VFS.
+func countLines(f *os.File, counts map[string]int) {
+	input := bufio.NewScanner(f)
+	for input.Scan() {
+		counts[input.Text()]++
+	}
+	// NOTE: ignoring potential errors from input.Err()
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2016, Stefan Lankes, RWTH Aachen University
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0, <LICENSE-APACHE or
+ * http://apache.org/licenses/LICENSE-2.0> or the MIT license <LICENSE-MIT or
+ * http://opensource.org/licenses/MIT>, at your option. This file may not be
+ * copied, modified, or distributed except according to those terms.
+ */
+
+// This example turns the runtime.MemStats snapshot that the pi demo prints
+// once at exit into a live, scrapeable endpoint on /metrics, reusing the
+// echo server's http.ListenAndServe setup.
+//
+// NOTE: this only covers the Go-side half of the request. The kernel-side
+// hcall and counters (resident pages, IRQs handled, VMEXIT counts under
+// KVM) are not implemented here: this chunk of the repo contains only
+// usr/tests, with no kernel source tree to add an hcall to.
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// snapshot is the set of values refreshed by the sampling goroutine below.
+type snapshot struct {
+	mu      sync.Mutex
+	mem     runtime.MemStats
+	samples []metrics.Sample
+	taken   time.Time
+}
+
+var current snapshot
+
+// sampleNames lists the runtime/metrics keys collected alongside
+// runtime.MemStats. See https://pkg.go.dev/runtime/metrics#pkg-variables for
+// the full catalogue; this is the subset relevant to GC pauses and
+// goroutine scheduling.
+var sampleNames = []string{
+	"/gc/pauses:seconds",
+	"/sched/goroutines:goroutines",
+	"/sched/latencies:seconds",
+}
+
+func init() {
+	current.samples = make([]metrics.Sample, len(sampleNames))
+	for i, name := range sampleNames {
+		current.samples[i].Name = name
+	}
+}
+
+// collect refreshes the snapshot. It is run periodically from main so that
+// /metrics always serves a recent value instead of forcing a fresh
+// (blocking) collection on every scrape.
+func collect() {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+
+	runtime.ReadMemStats(&current.mem)
+	metrics.Read(current.samples)
+	current.taken = time.Now()
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP hermit_mem_alloc_bytes Bytes of allocated heap objects.\n")
+	fmt.Fprintf(w, "# TYPE hermit_mem_alloc_bytes gauge\n")
+	fmt.Fprintf(w, "hermit_mem_alloc_bytes %d\n", current.mem.Alloc)
+
+	fmt.Fprintf(w, "# HELP hermit_mem_total_alloc_bytes Cumulative bytes allocated for heap objects.\n")
+	fmt.Fprintf(w, "# TYPE hermit_mem_total_alloc_bytes counter\n")
+	fmt.Fprintf(w, "hermit_mem_total_alloc_bytes %d\n", current.mem.TotalAlloc)
+
+	fmt.Fprintf(w, "# HELP hermit_mem_sys_bytes Total bytes obtained from the kernel.\n")
+	fmt.Fprintf(w, "# TYPE hermit_mem_sys_bytes gauge\n")
+	fmt.Fprintf(w, "hermit_mem_sys_bytes %d\n", current.mem.Sys)
+
+	for _, s := range current.samples {
+		switch s.Name {
+		case "/gc/pauses:seconds":
+			writeHistogram(w, "hermit_gc_pause_seconds", "Per-GC-cycle stop-the-world pause duration.", s.Value.Float64Histogram())
+		case "/sched/latencies:seconds":
+			writeHistogram(w, "hermit_sched_latency_seconds", "Time a goroutine spends waiting to run once runnable.", s.Value.Float64Histogram())
+		case "/sched/goroutines:goroutines":
+			fmt.Fprintf(w, "# HELP hermit_goroutines Number of live goroutines.\n")
+			fmt.Fprintf(w, "# TYPE hermit_goroutines gauge\n")
+			fmt.Fprintf(w, "hermit_goroutines %d\n", s.Value.Uint64())
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP hermit_metrics_last_collect_seconds Unix time of the last sample.\n")
+	fmt.Fprintf(w, "# TYPE hermit_metrics_last_collect_seconds gauge\n")
+	fmt.Fprintf(w, "hermit_metrics_last_collect_seconds %d\n", current.taken.Unix())
+}
+
+// writeHistogram renders h as a Prometheus histogram family named name.
+// h.Counts is the weight of each bucket; Prometheus wants the cumulative
+// count of observations <= the bucket's upper bound, plus a _sum/_count
+// pair.
+func writeHistogram(w http.ResponseWriter, name, help string, h *metrics.Float64Histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+
+	var cumulative uint64
+	var sum float64
+	for i, count := range h.Counts {
+		cumulative += count
+		upper := h.Buckets[i+1]
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, fmt.Sprintf("%g", upper), cumulative)
+
+		// runtime/metrics only gives us per-bucket counts, not the
+		// individual observed values, so approximate each observation in a
+		// bucket as having occurred at that bucket's upper bound (falling
+		// back to the lower bound for the +Inf bucket).
+		weight := upper
+		if math.IsInf(weight, 1) {
+			weight = h.Buckets[i]
+		}
+		sum += weight * float64(count)
+	}
+	fmt.Fprintf(w, "%s_sum %g\n", name, sum)
+	fmt.Fprintf(w, "%s_count %d\n", name, cumulative)
+}
+
+func main() {
+	fmt.Println("This example exports runtime.MemStats and GC/scheduler metrics")
+	fmt.Println("in Prometheus text format. Scrape it with:")
+	fmt.Println("curl http://localhost:9100/metrics")
+	fmt.Println("If KVM is implicitly started by our proxy, please open the port by")
+	fmt.Println("setting the environment variable HERMIT_APP_PORT to 9100.")
+
+	collect()
+	go func() {
+		for range time.Tick(time.Second) {
+			collect()
+		}
+	}()
+
+	http.HandleFunc("/metrics", metricsHandler)
+	log.Fatal(http.ListenAndServe(":9100", nil))
+}
@@ -0,0 +1,65 @@
+// Copyright © 2016 Alan A. A. Donovan & Brian W. Kernighan.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+// The original code was published at http://www.gopl.io, see page 21.
+
+// This is the "echo" server from server.go, served over TLS 1.3 instead of
+// plaintext. The certificate and key are bundled via embed so the example
+// has no filesystem dependency beyond the binary itself.
+package main
+
+import (
+	"crypto/tls"
+	_ "embed"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+//go:embed certs/server.crt
+var certPEM []byte
+
+//go:embed certs/server.key
+var keyPEM []byte
+
+func main() {
+	fmt.Println("This is an \"echo\" server that displays request parameters over TLS.")
+	fmt.Println("Start the server and send a https request to it (e.g.")
+	fmt.Println("curl -k https://localhost:8443/help). The server uses port 8443.")
+	fmt.Println("If KVM is implicitly started by our proxy, please open the port by")
+	fmt.Println("setting the environment variable HERMIT_APP_PORT to 8443.")
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := &http.Server{
+		Addr:    ":8443",
+		Handler: http.HandlerFunc(handler),
+		TLSConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS13,
+			Certificates: []tls.Certificate{cert},
+		},
+	}
+	log.Fatal(server.ListenAndServeTLS("", ""))
+}
+
+//!+handler
+// handler echoes the HTTP request.
+func handler(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "%s %s %s\n", r.Method, r.URL, r.Proto)
+	for k, v := range r.Header {
+		fmt.Fprintf(w, "Header[%q] = %q\n", k, v)
+	}
+	fmt.Fprintf(w, "Host = %q\n", r.Host)
+	fmt.Fprintf(w, "RemoteAddr = %q\n", r.RemoteAddr)
+	if err := r.ParseForm(); err != nil {
+		log.Print(err)
+	}
+	for k, v := range r.Form {
+		fmt.Fprintf(w, "Form[%q] = %q\n", k, v)
+	}
+}
+
+//!-handler